@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runAPICheck implements the `api-check` subcommand. It regenerates the
+// builder surface for -input-dir without writing the builder files
+// themselves, diffs the sorted list of exported function/method signatures
+// against the last checked-in api/builders.txt, and - in -ci mode - fails if
+// any entry disappeared or changed without a matching line in
+// api/next.txt. This mirrors the checked-in api/*.txt approach other
+// generators in this space (stringer, deepcopy-gen, controller-gen) use to
+// let downstream callers know a regeneration won't silently break them.
+func runAPICheck(args []string) error {
+	fs := flag.NewFlagSet("api-check", flag.ExitOnError)
+	var inputDir, style, out, allow string
+	var ci bool
+	fs.StringVar(&inputDir, "input-dir", "", "Directory containing API types")
+	fs.StringVar(&style, "style", string(styleOptions), "Builder API whose surface to check: options, fluent, or both")
+	fs.StringVar(&out, "out", "api/builders.txt", "Path to write the sorted builder surface to")
+	fs.StringVar(&allow, "allow", "api/next.txt", "Path to a newline-separated allow-list of signatures/function names permitted to change or disappear this run")
+	fs.BoolVar(&ci, "ci", false, "Fail instead of only reporting when an un-allow-listed entry changed or disappeared")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if inputDir == "" {
+		return fmt.Errorf("-input-dir is required")
+	}
+	genStyle, err := parseGenStyle(style)
+	if err != nil {
+		return err
+	}
+
+	surface, err := collectAPISurface(inputDir, genStyle)
+	if err != nil {
+		return fmt.Errorf("collecting builder surface: %w", err)
+	}
+
+	if ci {
+		previous, err := readSurfaceFile(out)
+		if err != nil {
+			return fmt.Errorf("reading previous %s: %w", out, err)
+		}
+		allowed, err := readAllowList(allow)
+		if err != nil {
+			return fmt.Errorf("reading allow-list %s: %w", allow, err)
+		}
+
+		current := make(map[string]bool, len(surface))
+		for _, sig := range surface {
+			current[sig] = true
+		}
+
+		var broken []string
+		for _, sig := range previous {
+			if current[sig] {
+				continue
+			}
+			if allowed[sig] || allowed[funcNameOf(sig)] {
+				continue
+			}
+			broken = append(broken, sig)
+		}
+		if len(broken) > 0 {
+			return fmt.Errorf("api-check: %d builder signature(s) removed or changed without a matching entry in %s:\n  %s",
+				len(broken), allow, strings.Join(broken, "\n  "))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(out), err)
+	}
+	return os.WriteFile(out, []byte(strings.Join(surface, "\n")+"\n"), 0644)
+}
+
+// collectAPISurface runs the ordinary per-file generation pipeline over
+// every .go file in inputDir and extracts the sorted, deduplicated set of
+// exported function and method signatures it would produce, without writing
+// any builder file to disk. It mirrors processFile's file-by-file walk, not
+// processModule's -recursive/go/packages one, so nested-builder helpers
+// that only resolve through whole-module type loading won't appear here;
+// run api-check against the same input the project actually generates
+// from (the -recursive caveat in processModule's doc comment applies here
+// too).
+func collectAPISurface(inputDir string, style genStyle) ([]string, error) {
+	seen := make(map[string]bool)
+
+	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		bg := &builderGenerator{
+			types:       make(map[string]*ast.TypeSpec),
+			typeMarkers: make(map[string]typeMarkers),
+			style:       style,
+		}
+		if err := bg.parseSource(string(content)); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if len(bg.types) == 0 {
+			return nil
+		}
+
+		output, err := bg.generate()
+		if err != nil {
+			return fmt.Errorf("generating %s: %w", path, err)
+		}
+
+		sigs, err := funcSignatures(output)
+		if err != nil {
+			return fmt.Errorf("extracting signatures from generated %s: %w", path, err)
+		}
+		for _, sig := range sigs {
+			seen[sig] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	surface := make([]string, 0, len(seen))
+	for sig := range seen {
+		surface = append(surface, sig)
+	}
+	sort.Strings(surface)
+	return surface, nil
+}
+
+// funcSignatures parses generated Go source and returns the bodiless
+// signature of every top-level function and method it declares, e.g.
+// `func NewFoo(opts ...func(*Foo)) *Foo` or
+// `func (b *FooBuilder) WithBar(bar string) *FooBuilder`.
+func funcSignatures(src []byte) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var sigs []string
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		bodiless := *funcDecl
+		bodiless.Body = nil
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, &bodiless); err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, buf.String())
+	}
+	return sigs, nil
+}
+
+// funcNameOf extracts the declared name from a rendered signature, so the
+// allow-list can permit a whole function ("WithBar") without having to
+// spell out its exact, possibly-changing parameter types.
+func funcNameOf(sig string) string {
+	sig = strings.TrimPrefix(sig, "func ")
+	if strings.HasPrefix(sig, "(") {
+		if idx := strings.Index(sig, ") "); idx != -1 {
+			sig = sig[idx+2:]
+		}
+	}
+	if idx := strings.Index(sig, "("); idx != -1 {
+		sig = sig[:idx]
+	}
+	return sig
+}
+
+func readSurfaceFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func readAllowList(path string) (map[string]bool, error) {
+	lines, err := readSurfaceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		allowed[strings.TrimSpace(line)] = true
+	}
+	return allowed, nil
+}
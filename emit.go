@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// processModule implements the -recursive mode: it loads the whole module
+// rooted at inputDir with go/packages and writes one builder file per
+// package, with an import block computed from what the generated code for
+// that package actually references - no more hand-maintained v1/metav1
+// workarounds.
+//
+// style is accepted for CLI symmetry with processFile, but only the
+// functional-options API is wired up here today; -recursive -style=fluent
+// isn't supported yet.
+func processModule(inputDir, outputDir string, style genStyle) error {
+	if style.hasFluent() {
+		return fmt.Errorf("-recursive does not support -style=%s yet", style)
+	}
+
+	idx, err := loadModule(inputDir, "./...")
+	if err != nil {
+		return err
+	}
+
+	for pkg, builderTypes := range idx.byPackage() {
+		output, imports, needsFmt, err := emitPackageBuilders(pkg, builderTypes)
+		if err != nil {
+			return fmt.Errorf("generating builders for %s: %w", pkg.PkgPath, err)
+		}
+
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "package %s\n\n", pkg.Name)
+		if needsFmt {
+			imports = append(imports, importSpec{path: "fmt"}.String())
+			sort.Strings(imports)
+		}
+		if len(imports) > 0 {
+			fmt.Fprintln(&buf, "import (")
+			for _, imp := range imports {
+				fmt.Fprintf(&buf, "\t%s\n", imp)
+			}
+			fmt.Fprintln(&buf, ")")
+			fmt.Fprintln(&buf)
+		}
+		buf.Write(output)
+
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("formatting builders for %s: %w", pkg.PkgPath, err)
+		}
+
+		// Keyed on the full package path (not just its leaf directory name)
+		// so two packages that happen to share a leaf, e.g. two "v1" dirs
+		// under different groups, don't overwrite each other's output.
+		outDir := filepath.Join(outputDir, filepath.FromSlash(pkg.PkgPath))
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("creating output dir for %s: %w", pkg.PkgPath, err)
+		}
+		outPath := filepath.Join(outDir, pkg.Name+"_builder.go")
+		if err := os.WriteFile(outPath, formatted, 0644); err != nil {
+			return fmt.Errorf("writing builders for %s: %w", pkg.PkgPath, err)
+		}
+	}
+
+	return nil
+}
+
+// emitPackageBuilders renders the New*/With* functions for every
+// +builder type declared in pkg, returning the rendered body, the sorted
+// deduplicated import block it needs (only packages that fields actually
+// resolved to, aliased the same way pkg's own source aliased them), and
+// whether any type had a +builder:required field (so the caller knows to
+// add the "fmt" import the resulting Validate() methods need).
+//
+// This mirrors builderGenerator.generate's field-marker handling
+// (appendSlice/mapKey/required, embedded ObjectMeta) so -recursive output
+// doesn't silently diverge from the per-file path for the same input.
+func emitPackageBuilders(pkg *packages.Package, builderTypes []*builderType) ([]byte, []string, bool, error) {
+	aliases := importAliasesOf(pkg)
+	used := make(map[string]importSpec)
+
+	// Unlike the per-file path's bg.types (scoped to one file), builderTypes
+	// here already spans every +builder type go/packages found anywhere in
+	// pkg, so a field referencing a sibling-file type resolves correctly.
+	localBuilderTypes := make(map[string]bool, len(builderTypes))
+	for _, bt := range builderTypes {
+		localBuilderTypes[bt.name] = true
+	}
+
+	qualifier := func(p *types.Package) string {
+		if p.Path() == pkg.PkgPath {
+			return ""
+		}
+		spec, ok := aliases[p.Path()]
+		if !ok {
+			spec = importSpec{path: p.Path()}
+		}
+		used[p.Path()] = spec
+		if spec.alias != "" {
+			return spec.alias
+		}
+		return p.Name()
+	}
+
+	var buf bytes.Buffer
+	var needsFmt bool
+	for _, bt := range builderTypes {
+		tm := parseTypeMarkers(bt.doc)
+		kind := bt.name
+		if tm.kind != "" {
+			kind = tm.kind
+		}
+		groupVersion := tm.groupVersion
+
+		// hasTypeMeta gates on the struct actually embedding a TypeMeta
+		// field, not just its name, so a +builder:groupVersion marker on a
+		// type with no TypeMeta field doesn't produce an unknown-field
+		// struct literal. embeddedField does a plain AST lookup with no
+		// resolution, so a TypeMeta field that turns out not to be stamped
+		// (no groupVersion) never touches the qualifier closure below - only
+		// resolve it, and register its import in used, once we know
+		// TypeMeta will actually be emitted.
+		resolve := func(e ast.Expr) string { return resolveFieldType(pkg, e, qualifier) }
+		hasTypeMeta := embeddedField(bt.structTy.Fields.List, "TypeMeta") != nil && groupVersion != ""
+		var typeMetaQualifier string
+		if hasTypeMeta {
+			_, typeMetaQualifier = embeddedFieldType(bt.structTy.Fields.List, "TypeMeta", resolve)
+			typeMetaQualifier = strings.TrimSuffix(typeMetaQualifier, ".TypeMeta")
+		}
+
+		if err := newFuncTmpl.Execute(&buf, struct {
+			Type         string
+			Kind         string
+			HasTypeMeta  bool
+			GroupVersion string
+			Qualifier    string
+		}{
+			Type:         bt.name,
+			Kind:         kind,
+			HasTypeMeta:  hasTypeMeta,
+			GroupVersion: groupVersion,
+			Qualifier:    typeMetaQualifier,
+		}); err != nil {
+			return nil, nil, false, err
+		}
+
+		var required []requiredField
+		visited := map[string]bool{bt.name: true}
+		for _, field := range bt.structTy.Fields.List {
+			if len(field.Names) == 0 {
+				if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+					generateEmbeddedFieldFuncs(&buf, bt.name, sel, resolve)
+				}
+				continue
+			}
+
+			fm := parseFieldMarkers(field.Doc)
+			if fm.skip {
+				continue
+			}
+
+			for _, name := range field.Names {
+				fieldType := resolveFieldType(pkg, field.Type, qualifier)
+				funcName := withFuncName(name.Name, fm)
+
+				var err error
+				switch {
+				case fm.appendSlice:
+					if arr, ok := field.Type.(*ast.ArrayType); ok && arr.Len == nil {
+						err = withAppendSliceFuncTmpl.Execute(&buf, struct {
+							FuncName, Field, ElemType, Type string
+						}{funcName, name.Name, resolveFieldType(pkg, arr.Elt, qualifier), bt.name})
+						break
+					}
+					fallthrough
+				case fm.mapKey:
+					if m, ok := field.Type.(*ast.MapType); ok {
+						err = withMapKeyFuncTmpl.Execute(&buf, struct {
+							FuncName, Field, KeyType, ValueType, Type string
+						}{funcName, name.Name, resolveFieldType(pkg, m.Key, qualifier), resolveFieldType(pkg, m.Value, qualifier), bt.name})
+						break
+					}
+					fallthrough
+				default:
+					err = withFuncTmpl.Execute(&buf, struct {
+						FuncName, Field, Param, FieldType, Type string
+					}{funcName, name.Name, strings.ToLower(name.Name), fieldType, bt.name})
+				}
+				if err != nil {
+					return nil, nil, false, err
+				}
+
+				if fm.required {
+					if zero := zeroExprFor(fieldType); zero != "" {
+						required = append(required, requiredField{Name: name.Name, ZeroExpr: zero})
+					}
+				}
+
+				nestedType, kind := resolveNestedField(field.Type, func(n string) bool { return localBuilderTypes[n] })
+				if err := renderNestedBuilderHelper(&buf, bt.name, name.Name, nestedType, kind, visited); err != nil {
+					return nil, nil, false, err
+				}
+			}
+		}
+
+		if len(required) > 0 {
+			needsFmt = true
+			if err := validateFuncTmpl.Execute(&buf, struct {
+				Type           string
+				RequiredFields []requiredField
+			}{bt.name, required}); err != nil {
+				return nil, nil, false, err
+			}
+		}
+	}
+
+	imports := make([]string, 0, len(used))
+	for _, spec := range used {
+		imports = append(imports, spec.String())
+	}
+	sort.Strings(imports)
+
+	return buf.Bytes(), imports, needsFmt, nil
+}
+
+// importAliasesOf reads the alias each import path was given in pkg's own
+// source, so generated code that references those packages reuses the same
+// qualifier instead of picking a fresh one.
+func importAliasesOf(pkg *packages.Package) map[string]importSpec {
+	aliases := make(map[string]importSpec)
+	for _, file := range pkg.Syntax {
+		for _, imp := range file.Imports {
+			path := strings.Trim(imp.Path.Value, `"`)
+			spec := importSpec{path: path}
+			if imp.Name != nil {
+				spec.alias = imp.Name.Name
+			}
+			aliases[path] = spec
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strings"
+	"text/template"
+)
+
+var (
+	builderStructTmpl = template.Must(template.New("builderStruct").Parse(
+		`// {{.Type}}Builder builds a {{.Type}} through chained With* calls.
+type {{.Type}}Builder struct {
+	obj *{{.Type}}
+}
+
+// New{{.Type}}Builder returns a {{.Type}}Builder seeded with a zero-value {{.Type}}.
+func New{{.Type}}Builder() *{{.Type}}Builder {
+	b := &{{.Type}}Builder{obj: &{{.Type}}{}}
+{{- if .HasTypeMeta}}
+	b.obj.TypeMeta = v1.TypeMeta{
+		Kind:       "{{.Kind}}",
+		APIVersion: "{{.GroupVersion}}",
+	}
+{{- end}}
+	return b
+}
+
+// Build returns the {{.Type}} assembled so far.
+func (b *{{.Type}}Builder) Build() *{{.Type}} {
+	return b.obj
+}
+
+// BuildInto copies the {{.Type}} assembled so far into dst.
+func (b *{{.Type}}Builder) BuildInto(dst *{{.Type}}) {
+	*dst = *b.obj
+}
+
+`))
+
+	fluentWithFuncTmpl = template.Must(template.New("fluentWithFunc").Parse(
+		`// {{.FuncName}} sets the {{.Field}} of the {{.Type}} being built.
+func (b *{{.Type}}Builder) {{.FuncName}}({{.Param}} {{.FieldType}}) *{{.Type}}Builder {
+	b.obj.{{.Field}} = {{.Param}}
+	return b
+}
+
+`))
+
+	fluentAddFuncTmpl = template.Must(template.New("fluentAddFunc").Parse(
+		`// {{.FuncName}} appends to the {{.Field}} of the {{.Type}} being built.
+func (b *{{.Type}}Builder) {{.FuncName}}(items ...{{.ElemType}}) *{{.Type}}Builder {
+	b.obj.{{.Field}} = append(b.obj.{{.Field}}, items...)
+	return b
+}
+
+`))
+
+	fluentAddMapFuncTmpl = template.Must(template.New("fluentAddMapFunc").Parse(
+		`// {{.FuncName}} sets a key of the {{.Field}} map of the {{.Type}} being built.
+func (b *{{.Type}}Builder) {{.FuncName}}(k {{.KeyType}}, v {{.ValueType}}) *{{.Type}}Builder {
+	if b.obj.{{.Field}} == nil {
+		b.obj.{{.Field}} = make(map[{{.KeyType}}]{{.ValueType}})
+	}
+	b.obj.{{.Field}}[k] = v
+	return b
+}
+
+`))
+)
+
+// generateFluentBuilder renders the FooBuilder struct, constructor,
+// chainable With* methods and Build/BuildInto pair for typeName. Slice and
+// map fields get both a replace-style With* method (handled by the caller
+// alongside every other field) and an additive Add* method.
+func (bg *builderGenerator) generateFluentBuilder(buf *bytes.Buffer, typeName string, structType *ast.StructType, kind, groupVersion string) error {
+	if err := builderStructTmpl.Execute(buf, struct {
+		Type, Kind, GroupVersion string
+		HasTypeMeta              bool
+	}{
+		Type:         typeName,
+		Kind:         kind,
+		GroupVersion: groupVersion,
+		HasTypeMeta:  groupVersion != "" && !strings.Contains(typeName, "Spec") && !strings.Contains(typeName, "Status"),
+	}); err != nil {
+		return fmt.Errorf("rendering %sBuilder: %w", typeName, err)
+	}
+
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 {
+			if sel, ok := field.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "ObjectMeta" {
+				bg.generateFluentObjectMetaFuncs(buf, typeName)
+			}
+			continue
+		}
+
+		fm := parseFieldMarkers(field.Doc)
+		if fm.skip {
+			continue
+		}
+
+		for _, name := range field.Names {
+			funcName := withFuncName(name.Name, fm)
+			fieldType := bg.renderFieldType(field.Type)
+
+			if err := fluentWithFuncTmpl.Execute(buf, struct {
+				FuncName, Field, Param, FieldType, Type string
+			}{funcName, name.Name, strings.ToLower(name.Name), fieldType, typeName}); err != nil {
+				return fmt.Errorf("rendering %s.%s: %w", typeName, funcName, err)
+			}
+
+			switch t := field.Type.(type) {
+			case *ast.ArrayType:
+				if t.Len != nil {
+					continue
+				}
+				if err := fluentAddFuncTmpl.Execute(buf, struct {
+					FuncName, Field, ElemType, Type string
+				}{"Add" + name.Name, name.Name, bg.renderFieldType(t.Elt), typeName}); err != nil {
+					return fmt.Errorf("rendering %s.Add%s: %w", typeName, name.Name, err)
+				}
+			case *ast.MapType:
+				if err := fluentAddMapFuncTmpl.Execute(buf, struct {
+					FuncName, Field, KeyType, ValueType, Type string
+				}{"Add" + singularize(name.Name), name.Name, bg.renderFieldType(t.Key), bg.renderFieldType(t.Value), typeName}); err != nil {
+					return fmt.Errorf("rendering %s.Add%s: %w", typeName, singularize(name.Name), err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// singularize trims a trailing "s" so a map field named Labels gets an
+// AddLabel helper instead of the grammatically awkward AddLabels(k, v).
+// This is a heuristic, not a real pluralization rule - it only needs to
+// read naturally for the common case of a plain plural field name.
+func singularize(name string) string {
+	if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+func (bg *builderGenerator) generateFluentObjectMetaFuncs(buf *bytes.Buffer, typeName string) {
+	fmt.Fprintf(buf, "// WithName sets the name of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithName(name string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.Name = name\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// WithNamespace sets the namespace of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithNamespace(namespace string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.Namespace = namespace\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// WithLabels replaces the labels of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithLabels(labels map[string]string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.Labels = labels\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// AddLabel sets a single label of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) AddLabel(k, v string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tif b.obj.Labels == nil {\n\t\tb.obj.Labels = make(map[string]string)\n\t}\n")
+	fmt.Fprintf(buf, "\tb.obj.Labels[k] = v\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// WithAnnotations replaces the annotations of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithAnnotations(annotations map[string]string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.Annotations = annotations\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// AddAnnotation sets a single annotation of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) AddAnnotation(k, v string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tif b.obj.Annotations == nil {\n\t\tb.obj.Annotations = make(map[string]string)\n\t}\n")
+	fmt.Fprintf(buf, "\tb.obj.Annotations[k] = v\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// AddFinalizer appends a finalizer to the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) AddFinalizer(f string) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.Finalizers = append(b.obj.Finalizers, f)\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// WithCreationTimestamp sets the creation timestamp of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithCreationTimestamp(timestamp v1.Time) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.CreationTimestamp = timestamp\n\treturn b\n}\n\n")
+
+	fmt.Fprintf(buf, "// WithDeletionTimestamp sets the deletion timestamp of the %s being built.\n", typeName)
+	fmt.Fprintf(buf, "func (b *%sBuilder) WithDeletionTimestamp(timestamp *v1.Time) *%sBuilder {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\tb.obj.DeletionTimestamp = timestamp\n\treturn b\n}\n\n")
+}
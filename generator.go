@@ -1,31 +1,49 @@
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
-	"go/token"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-// builderGenerator holds the state for generating builder code
+// builderGenerator holds the state for generating builder code for a single
+// input file.
 type builderGenerator struct {
-	pkg     string
-	imports []string
-	types   map[string]*ast.TypeSpec
+	pkg         string
+	imports     []importSpec
+	types       map[string]*ast.TypeSpec
+	typeMarkers map[string]typeMarkers
+
+	// typeInfo/typePkg come from type-checking the parsed file with go/types.
+	// They are best-effort: selector fields that reference packages we can't
+	// resolve (e.g. not present in GOPATH/module cache) simply have no entry,
+	// and renderFieldType falls back to AST-based rendering for those.
+	typeInfo *typeInfo
+
+	style genStyle
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "api-check" {
+		if err := runAPICheck(os.Args[2:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
 	var inputDir string
 	var outputDir string
+	var recursive bool
+	var style string
 
 	flag.StringVar(&inputDir, "input-dir", "", "Directory containing API types")
 	flag.StringVar(&outputDir, "output-dir", "", "Directory for generated code")
+	flag.BoolVar(&recursive, "recursive", false, "Load input-dir as a module with go/packages instead of walking files individually, resolving types across the whole package graph")
+	flag.StringVar(&style, "style", string(styleOptions), "Builder API to generate: options, fluent, or both")
 	flag.Parse()
 
 	if inputDir == "" || outputDir == "" {
@@ -33,12 +51,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	genStyle, err := parseGenStyle(style)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
+	if recursive {
+		if err := processModule(inputDir, outputDir, genStyle); err != nil {
+			log.Fatalf("Error processing module: %v", err)
+		}
+		return
+	}
+
 	// Process .go files in input directory
-	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -47,7 +77,7 @@ func main() {
 			return nil
 		}
 
-		return processFile(path, outputDir)
+		return processFile(path, outputDir, genStyle)
 	})
 
 	if err != nil {
@@ -55,14 +85,16 @@ func main() {
 	}
 }
 
-func processFile(inputPath, outputDir string) error {
+func processFile(inputPath, outputDir string, style genStyle) error {
 	content, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("reading input file: %w", err)
 	}
 
 	bg := &builderGenerator{
-		types: make(map[string]*ast.TypeSpec),
+		types:       make(map[string]*ast.TypeSpec),
+		typeMarkers: make(map[string]typeMarkers),
+		style:       style,
 	}
 
 	if err := bg.parseSource(string(content)); err != nil {
@@ -90,198 +122,3 @@ func processFile(inputPath, outputDir string) error {
 	log.Printf("Generated builder code for %s in %s", inputPath, outputPath)
 	return nil
 }
-
-func (bg *builderGenerator) parseSource(src string) error {
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
-	if err != nil {
-		return fmt.Errorf("parsing source: %w", err)
-	}
-
-	bg.pkg = file.Name.Name
-
-	// Collect imports, this has still some issue and requires file type to have v1 instead of metav1 for example
-	// and needs a manual change to not make the import be deleted automatically by the linter.
-	for _, imp := range file.Imports {
-		bg.imports = append(bg.imports, imp.Path.Value)
-	}
-
-	// Find types with +builder tag
-	for _, decl := range file.Decls {
-		genDecl, ok := decl.(*ast.GenDecl)
-		if !ok {
-			continue
-		}
-
-		for _, spec := range genDecl.Specs {
-			typeSpec, ok := spec.(*ast.TypeSpec)
-			if !ok {
-				continue
-			}
-
-			hasBuilderTag := false
-			if genDecl.Doc != nil {
-				for _, comment := range genDecl.Doc.List {
-					if strings.Contains(comment.Text, "+builder") {
-						hasBuilderTag = true
-						break
-					}
-				}
-			}
-
-			if hasBuilderTag {
-				bg.types[typeSpec.Name.Name] = typeSpec
-			}
-		}
-	}
-
-	return nil
-}
-
-func (bg *builderGenerator) generate() ([]byte, error) {
-	var buf bytes.Buffer
-
-	// Package declaration
-	fmt.Fprintf(&buf, "package %s\n\n", bg.pkg)
-
-	// Imports
-	if len(bg.imports) > 0 {
-		fmt.Fprintln(&buf, "import (")
-		for _, imp := range bg.imports {
-			fmt.Fprintf(&buf, "\t%s\n", imp)
-		}
-		fmt.Fprintln(&buf, ")")
-		fmt.Fprintln(&buf)
-	}
-
-	// Generate builder functions for each type
-	for typeName, typeSpec := range bg.types {
-		structType, ok := typeSpec.Type.(*ast.StructType)
-		if !ok {
-			continue
-		}
-
-		// New functions
-		fmt.Fprintf(&buf, "// New%s returns a %s object with the given options\n", typeName, typeName)
-		parentTypeName := typeName
-		fmt.Fprintf(&buf, "func New%s(opts ...func(*%s)) *%s {\n", typeName, parentTypeName, parentTypeName)
-		fmt.Fprintf(&buf, "\tobj := &%s{\n", typeName)
-		if !strings.Contains(typeName, "Spec") && !strings.Contains(typeName, "Status") {
-			fmt.Fprintf(&buf, "\t\tTypeMeta: v1.TypeMeta{\n")
-			fmt.Fprintf(&buf, "\t\t\tKind:       %q,\n", parentTypeName)
-			fmt.Fprintf(&buf, "\t\t\tAPIVersion: %q,\n", "stack.civo.com/v1alpha1")
-			fmt.Fprintf(&buf, "\t\t},\n")
-		}
-		fmt.Fprintln(&buf, "\t}")
-		fmt.Fprintln(&buf)
-
-		fmt.Fprintln(&buf, "\tfor _, f := range opts {")
-		fmt.Fprintln(&buf, "\t\tf(obj)")
-		fmt.Fprintln(&buf, "\t}")
-		fmt.Fprintln(&buf)
-		fmt.Fprintln(&buf, "\treturn obj")
-		fmt.Fprintln(&buf, "}")
-		fmt.Fprintln(&buf)
-
-		// Generate With* functions for fields
-		for _, field := range structType.Fields.List {
-			if len(field.Names) == 0 {
-				// Handle embedded fields
-				switch typeExpr := field.Type.(type) {
-				case *ast.SelectorExpr:
-					bg.generateEmbeddedFieldFuncs(&buf, typeName, typeExpr)
-				}
-				continue
-			}
-
-			for _, name := range field.Names {
-				fieldType := renderType(field.Type)
-				fmt.Fprintf(&buf, "// With%s sets the %s of a %s\n", name, name, typeName)
-				fmt.Fprintf(&buf, "func With%s(%s %s) func(*%s) {\n", name, strings.ToLower(name.Name), fieldType, typeName)
-				fmt.Fprintf(&buf, "\treturn func(obj *%s) {\n", typeName)
-				fmt.Fprintf(&buf, "\t\tobj.%s = %s\n", name, strings.ToLower(name.Name))
-				fmt.Fprintf(&buf, "\t}\n")
-				fmt.Fprintf(&buf, "}\n\n")
-			}
-		}
-	}
-
-	return buf.Bytes(), nil
-}
-
-func (bg *builderGenerator) generateEmbeddedFieldFuncs(buf *bytes.Buffer, typeName string, typeExpr *ast.SelectorExpr) {
-	if typeExpr.Sel.Name == "ObjectMeta" {
-		// WithName
-		fmt.Fprintf(buf, "// WithName sets the name of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithName(name string) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.Name = name\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithNamespace
-		fmt.Fprintf(buf, "// WithNamespace sets the namespace of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithNamespace(namespace string) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.Namespace = namespace\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithLabels
-		fmt.Fprintf(buf, "// WithLabel sets a label of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithLabel(k, v string) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.Labels[k] = v\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithAnnotations
-		fmt.Fprintf(buf, "// WithAnnotation sets an annotation of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithAnnotation(k, v string) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.Annotations[k] = v\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithFinalizers
-		fmt.Fprintf(buf, "// WithFinalizer sets the finalizers of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithFinalizer(f string) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.Finalizers = append(obj.Finalizers, f)\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithCreationTimestamp
-		fmt.Fprintf(buf, "// WithCreationTimestamp sets the deletion timestamp of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithCreationTimestamp(timestamp v1.Time) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.CreationTimestamp = timestamp\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-
-		// WithDeletionTimestamp
-		fmt.Fprintf(buf, "// WithDeletionTimestamp sets the deletion timestamp of the %s\n", typeName)
-		fmt.Fprintf(buf, "func WithDeletionTimestamp(timestamp *v1.Time) func(*%s) {\n", typeName)
-		fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
-		fmt.Fprintf(buf, "\t\tobj.DeletionTimestamp = timestamp\n")
-		fmt.Fprintf(buf, "\t}\n")
-		fmt.Fprintf(buf, "}\n\n")
-	}
-}
-
-func renderType(expr ast.Expr) string {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		return "*" + renderType(t.X)
-	case *ast.SelectorExpr:
-		return renderType(t.X) + "." + t.Sel.Name
-	case *ast.ArrayType:
-		return "[]" + renderType(t.Elt)
-	case *ast.MapType:
-		return fmt.Sprintf("map[%s]%s", renderType(t.Key), renderType(t.Value))
-	default:
-		return fmt.Sprintf("unsupported-%T", expr)
-	}
-}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// markerPrefix is the namespace every structured marker lives under, in the
+// spirit of controller-gen/kubebuilder markers: `+builder:key=value`. The
+// bare `+builder` marker (no colon) is still what opts a type into builder
+// generation at all; everything else here only refines that.
+const markerPrefix = "+builder:"
+
+// typeMarkers is everything a type's doc comment can configure beyond the
+// bare +builder opt-in.
+type typeMarkers struct {
+	skip         bool
+	groupVersion string // TypeMeta.APIVersion; TypeMeta is omitted entirely when unset
+	kind         string // overrides the type name used for TypeMeta.Kind when set
+}
+
+// fieldMarkers is everything a field's doc comment can configure about its
+// generated With* helper.
+type fieldMarkers struct {
+	skip        bool
+	required    bool
+	name        string // overrides the generated function name (e.g. "WithCount") when set
+	appendSlice bool   // slice fields: emit a variadic append helper instead of a replace helper
+	mapKey      bool   // map fields: emit a (k, v) helper instead of a whole-map replace helper
+}
+
+// parseTypeMarkers reads the +builder: markers off a type's doc comment.
+func parseTypeMarkers(doc *ast.CommentGroup) typeMarkers {
+	var tm typeMarkers
+	for _, kv := range markerValues(doc) {
+		switch kv.key {
+		case "skip":
+			tm.skip = true
+		case "groupVersion":
+			tm.groupVersion = kv.value
+		case "kind":
+			tm.kind = kv.value
+		}
+	}
+	return tm
+}
+
+// parseFieldMarkers reads the +builder: markers off a field's doc comment.
+func parseFieldMarkers(doc *ast.CommentGroup) fieldMarkers {
+	var fm fieldMarkers
+	for _, kv := range markerValues(doc) {
+		switch kv.key {
+		case "skip":
+			fm.skip = true
+		case "required":
+			fm.required = true
+		case "name":
+			fm.name = kv.value
+		case "appendSlice":
+			fm.appendSlice = true
+		case "mapKey":
+			fm.mapKey = true
+		}
+	}
+	return fm
+}
+
+type markerKV struct {
+	key   string
+	value string
+}
+
+// markerValues extracts every `+builder:key` / `+builder:key=value` marker
+// from doc, in source order. Markers without a comment group (doc == nil)
+// yield no values, which keeps every caller's zero-value defaults intact.
+func markerValues(doc *ast.CommentGroup) []markerKV {
+	if doc == nil {
+		return nil
+	}
+
+	var kvs []markerKV
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if !strings.HasPrefix(text, markerPrefix) {
+			continue
+		}
+		text = strings.TrimPrefix(text, markerPrefix)
+
+		key, value, hasValue := strings.Cut(text, "=")
+		kv := markerKV{key: strings.TrimSpace(key)}
+		if hasValue {
+			kv.value = strings.TrimSpace(value)
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs
+}
+
+// withFuncName returns the exported function name to use for field, honoring
+// a `+builder:name=...` override when present.
+func withFuncName(fieldName string, fm fieldMarkers) string {
+	if fm.name != "" {
+		return fm.name
+	}
+	return "With" + fieldName
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packagesLoadMode is what we need from go/packages to resolve struct field
+// types across the whole module: syntax trees to find +builder markers,
+// type info to follow named types across files and packages, and the
+// import graph so the output file only imports what it actually uses.
+const packagesLoadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedTypes |
+	packages.NeedTypesInfo |
+	packages.NeedSyntax
+
+// pkgIndex is the whole-module counterpart to the single-file
+// builderGenerator: every +builder type is indexed by its fully qualified
+// name (package path + type name) so a field referencing a type declared in
+// a sibling file, or a different package entirely, resolves instead of
+// turning into an opaque identifier the way processFile's file-by-file walk
+// does.
+type pkgIndex struct {
+	pkgs  []*packages.Package
+	types map[string]*builderType // "import/path.TypeName" -> type
+}
+
+// builderType is a single +builder-annotated struct found anywhere in the
+// loaded module, along with the package it belongs to so generated code can
+// compute a correct, minimal import block.
+type builderType struct {
+	pkg      *packages.Package
+	name     string
+	doc      *ast.CommentGroup // the +builder marker comment, from the enclosing GenDecl
+	typeSpec *ast.TypeSpec
+	structTy *ast.StructType
+}
+
+// loadModule loads every package under patterns (typically "./...") rooted
+// at dir and indexes their +builder-annotated struct types. Unlike
+// processFile, which parses one file at a time with go/parser, this gives
+// every field's type a real *types.Named to resolve through, so a field of
+// type FooSpec declared in another file of the same package - or another
+// package altogether - is no longer an opaque identifier.
+func loadModule(dir string, patterns ...string) (*pkgIndex, error) {
+	cfg := &packages.Config{
+		Mode: packagesLoadMode,
+		Dir:  dir,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+
+	var errs []error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e)
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("loading packages: %d errors, first: %w", len(errs), errs[0])
+	}
+
+	idx := &pkgIndex{
+		pkgs:  pkgs,
+		types: make(map[string]*builderType),
+	}
+
+	for _, p := range pkgs {
+		for _, file := range p.Syntax {
+			for _, decl := range file.Decls {
+				genDecl, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range genDecl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					structTy, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					if !hasBuilderMarker(genDecl.Doc) {
+						continue
+					}
+					if parseTypeMarkers(genDecl.Doc).skip {
+						continue
+					}
+
+					idx.types[p.PkgPath+"."+typeSpec.Name.Name] = &builderType{
+						pkg:      p,
+						name:     typeSpec.Name.Name,
+						doc:      genDecl.Doc,
+						typeSpec: typeSpec,
+						structTy: structTy,
+					}
+				}
+			}
+		}
+	}
+
+	return idx, nil
+}
+
+// byPackage groups the indexed +builder types by the package that declares
+// them, so emitBuilders can write one output file per package the way
+// processFile writes one output file per input file today.
+func (idx *pkgIndex) byPackage() map[*packages.Package][]*builderType {
+	grouped := make(map[*packages.Package][]*builderType)
+	for _, bt := range idx.types {
+		grouped[bt.pkg] = append(grouped[bt.pkg], bt)
+	}
+	for _, types := range grouped {
+		sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+	}
+	return grouped
+}
+
+// resolveFieldType returns the fully qualified rendering of a struct
+// field's type using the type information go/packages attached to pkg,
+// falling back to AST rendering when the checker couldn't resolve it (for
+// example a field typed with a generic type parameter, which renderType
+// doesn't understand either).
+func resolveFieldType(pkg *packages.Package, expr ast.Expr, qual types.Qualifier) string {
+	if tv, ok := pkg.TypesInfo.Types[expr]; ok && tv.Type != nil && tv.Type != types.Typ[types.Invalid] {
+		return types.TypeString(tv.Type, qual)
+	}
+	return renderType(expr)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"text/template"
+)
+
+var (
+	mutateFieldTmpl = template.Must(template.New("mutateField").Parse(
+		`// Mutate{{.Field}} lets the caller configure the {{.Field}} of a {{.Type}} in place.
+func Mutate{{.Field}}(fn func(*{{.NestedType}})) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		fn(&obj.{{.Field}})
+	}
+}
+
+`))
+
+	mutatePointerFieldTmpl = template.Must(template.New("mutatePointerField").Parse(
+		`// Mutate{{.Field}} lets the caller configure the {{.Field}} of a {{.Type}} in place, allocating it if necessary.
+func Mutate{{.Field}}(fn func(*{{.NestedType}})) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		if obj.{{.Field}} == nil {
+			obj.{{.Field}} = &{{.NestedType}}{}
+		}
+		fn(obj.{{.Field}})
+	}
+}
+
+`))
+
+	addElementFieldTmpl = template.Must(template.New("addElementField").Parse(
+		`// Add{{.Singular}} appends a new {{.NestedType}} to the {{.Field}} of a {{.Type}}, configured via fn.
+func Add{{.Singular}}(fn func(*{{.NestedType}})) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		var item {{.NestedType}}
+		fn(&item)
+		obj.{{.Field}} = append(obj.{{.Field}}, item)
+	}
+}
+
+`))
+)
+
+// nestedFieldKind classifies how a field refers to another +builder type, so
+// generateNestedBuilderHelpers knows which of Mutate.../Add... to emit.
+type nestedFieldKind int
+
+const (
+	nestedNone nestedFieldKind = iota
+	nestedDirect
+	nestedPointer
+	nestedSlice
+)
+
+// resolveNestedField inspects a field's AST type and reports whether it
+// refers directly, through a pointer, or through a slice to another
+// +builder-annotated type, as decided by isBuilderType. Qualified
+// (cross-package) types are left alone - isBuilderType is only ever asked
+// about bare identifiers, so a nested type only resolves when it's visible
+// in whatever scope the caller built isBuilderType from.
+func resolveNestedField(expr ast.Expr, isBuilderType func(name string) bool) (name string, kind nestedFieldKind) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if isBuilderType(t.Name) {
+			return t.Name, nestedDirect
+		}
+	case *ast.StarExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			if isBuilderType(ident.Name) {
+				return ident.Name, nestedPointer
+			}
+		}
+	case *ast.ArrayType:
+		if t.Len == nil {
+			if ident, ok := t.Elt.(*ast.Ident); ok {
+				if isBuilderType(ident.Name) {
+					return ident.Name, nestedSlice
+				}
+			}
+		}
+	}
+	return "", nestedNone
+}
+
+// nestedFieldType is resolveNestedField scoped to bg.types, i.e. the
+// +builder types declared in the single file being processed.
+func (bg *builderGenerator) nestedFieldType(expr ast.Expr) (name string, kind nestedFieldKind) {
+	return resolveNestedField(expr, func(name string) bool {
+		_, ok := bg.types[name]
+		return ok
+	})
+}
+
+// generateNestedBuilderHelpers emits a Mutate*/Add* helper for field when it
+// refers to another +builder type in the same file, letting callers
+// configure the nested value inline instead of constructing it by hand.
+// visited guards against a field whose nested type is already an ancestor
+// in the current chain of nested-helper generation (e.g. a type that embeds
+// itself), so a self-referential or mutually-recursive set of +builder
+// types can't make this loop forever.
+func (bg *builderGenerator) generateNestedBuilderHelpers(buf *bytes.Buffer, typeName, fieldName string, expr ast.Expr, visited map[string]bool) error {
+	nestedType, kind := bg.nestedFieldType(expr)
+	return renderNestedBuilderHelper(buf, typeName, fieldName, nestedType, kind, visited)
+}
+
+// renderNestedBuilderHelper emits the Mutate*/Add* helper for an
+// already-resolved nested field reference. Shared by the per-file
+// (generateNestedBuilderHelpers) and -recursive (emitPackageBuilders) paths
+// so both honor the same visited/cycle-guard semantics.
+func renderNestedBuilderHelper(buf *bytes.Buffer, typeName, fieldName, nestedType string, kind nestedFieldKind, visited map[string]bool) error {
+	if kind == nestedNone {
+		return nil
+	}
+	if visited[nestedType] {
+		return nil
+	}
+	visited[nestedType] = true
+	defer delete(visited, nestedType)
+
+	switch kind {
+	case nestedDirect:
+		return mutateFieldTmpl.Execute(buf, struct{ Field, Type, NestedType string }{fieldName, typeName, nestedType})
+	case nestedPointer:
+		return mutatePointerFieldTmpl.Execute(buf, struct{ Field, Type, NestedType string }{fieldName, typeName, nestedType})
+	case nestedSlice:
+		return addElementFieldTmpl.Execute(buf, struct{ Singular, Field, Type, NestedType string }{singularize(fieldName), fieldName, typeName, nestedType})
+	default:
+		return fmt.Errorf("unreachable nested field kind %d", kind)
+	}
+}
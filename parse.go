@@ -0,0 +1,149 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// importSpec is a single import line from the source file, keeping the
+// explicit alias the user wrote (if any) so generated code can reference the
+// same qualifier instead of re-deriving one from the package name. This is
+// what used to get lost when imports were re-emitted from raw path strings,
+// e.g. `v1 "k8s.io/apimachinery/pkg/apis/meta/v1"` turning into an
+// unaliased `metav1` import.
+type importSpec struct {
+	alias string // empty when the import has no explicit alias
+	path  string // unquoted import path
+}
+
+// String renders the import spec the way it would appear inside an import
+// block: `alias "path"` or just `"path"`.
+func (i importSpec) String() string {
+	if i.alias == "" {
+		return `"` + i.path + `"`
+	}
+	return i.alias + ` "` + i.path + `"`
+}
+
+// typeInfo wraps the result of best-effort type-checking the source file.
+// Resolution is not guaranteed: imports that aren't available to go/types's
+// importer (anything not vendored or installed under GOPATH, which in
+// practice means most Kubernetes API packages) simply won't have entries in
+// Types, and callers fall back to AST-based rendering for those.
+type typeInfo struct {
+	fset  *token.FileSet
+	info  *types.Info
+	pkg   *types.Package
+	file  *ast.File
+	spans map[string]importSpec // import path -> spec, for the qualifier
+}
+
+// hasBuilderMarker reports whether doc contains a bare "+builder" marker
+// comment. It's shared between the single-file walk in parseSource and the
+// whole-module walk in loadModule.
+func hasBuilderMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.Contains(comment.Text, "+builder") {
+			return true
+		}
+	}
+	return false
+}
+
+func (bg *builderGenerator) parseSource(src string) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	bg.pkg = file.Name.Name
+
+	// Collect imports, keeping whatever alias the user wrote so generated
+	// code can reuse it verbatim instead of guessing a package name.
+	imports := make(map[string]importSpec)
+	for _, imp := range file.Imports {
+		spec := importSpec{path: strings.Trim(imp.Path.Value, `"`)}
+		if imp.Name != nil {
+			spec.alias = imp.Name.Name
+		}
+		bg.imports = append(bg.imports, spec)
+		imports[spec.path] = spec
+	}
+
+	// Find types with +builder tag
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			if hasBuilderMarker(genDecl.Doc) {
+				tm := parseTypeMarkers(genDecl.Doc)
+				if tm.skip {
+					continue
+				}
+				bg.types[typeSpec.Name.Name] = typeSpec
+				bg.typeMarkers[typeSpec.Name.Name] = tm
+			}
+		}
+	}
+
+	// Best-effort type-check so renderFieldType can resolve field types
+	// through go/types (e.g. named types across local files, aliases,
+	// dot-imports) instead of re-deriving them from the raw AST. Errors are
+	// swallowed deliberately: most real-world inputs import packages (k8s.io
+	// types and friends) that this single-file check has no way to load, so
+	// we keep whatever partial type info comes back and fall back to AST
+	// rendering for anything it couldn't resolve.
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: importer.Default(),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(bg.pkg, fset, []*ast.File{file}, info)
+
+	bg.typeInfo = &typeInfo{
+		fset:  fset,
+		info:  info,
+		pkg:   pkg,
+		file:  file,
+		spans: imports,
+	}
+
+	return nil
+}
+
+// qualifier returns a types.Qualifier that prefers the alias the source file
+// originally used for a given import path over whatever name go/types would
+// otherwise pick from the package's declared name.
+func (bg *builderGenerator) qualifier() types.Qualifier {
+	return func(p *types.Package) string {
+		if bg.typeInfo.pkg != nil && p.Path() == bg.typeInfo.pkg.Path() {
+			return ""
+		}
+		if spec, ok := bg.typeInfo.spans[p.Path()]; ok {
+			if spec.alias != "" {
+				return spec.alias
+			}
+		}
+		return p.Name()
+	}
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+var (
+	headerTmpl = template.Must(template.New("header").Parse(
+		`package {{.Pkg}}
+{{if .Imports}}
+import (
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{end}}`))
+
+	newFuncTmpl = template.Must(template.New("newFunc").Parse(
+		`// New{{.Type}} returns a {{.Type}} object with the given options
+func New{{.Type}}(opts ...func(*{{.Type}})) *{{.Type}} {
+	obj := &{{.Type}}{
+{{- if .HasTypeMeta}}
+		TypeMeta: {{.Qualifier}}.TypeMeta{
+			Kind:       "{{.Kind}}",
+			APIVersion: "{{.GroupVersion}}",
+		},
+{{- end}}
+	}
+
+	for _, f := range opts {
+		f(obj)
+	}
+
+	return obj
+}
+
+`))
+
+	withFuncTmpl = template.Must(template.New("withFunc").Parse(
+		`// {{.FuncName}} sets the {{.Field}} of a {{.Type}}
+func {{.FuncName}}({{.Param}} {{.FieldType}}) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		obj.{{.Field}} = {{.Param}}
+	}
+}
+
+`))
+
+	withAppendSliceFuncTmpl = template.Must(template.New("withAppendSliceFunc").Parse(
+		`// {{.FuncName}} appends to the {{.Field}} of a {{.Type}}
+func {{.FuncName}}(items ...{{.ElemType}}) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		obj.{{.Field}} = append(obj.{{.Field}}, items...)
+	}
+}
+
+`))
+
+	withMapKeyFuncTmpl = template.Must(template.New("withMapKeyFunc").Parse(
+		`// {{.FuncName}} sets a key of the {{.Field}} map of a {{.Type}}
+func {{.FuncName}}(k {{.KeyType}}, v {{.ValueType}}) func(*{{.Type}}) {
+	return func(obj *{{.Type}}) {
+		if obj.{{.Field}} == nil {
+			obj.{{.Field}} = make(map[{{.KeyType}}]{{.ValueType}})
+		}
+		obj.{{.Field}}[k] = v
+	}
+}
+
+`))
+
+	validateFuncTmpl = template.Must(template.New("validateFunc").Parse(
+		`// Validate checks that the required fields of {{.Type}} are set.
+func (obj *{{.Type}}) Validate() error {
+{{- range .RequiredFields}}
+	if obj.{{.Name}} == {{.ZeroExpr}} {
+		return fmt.Errorf("{{$.Type}}.{{.Name}} is required")
+	}
+{{- end}}
+	return nil
+}
+
+`))
+)
+
+// requiredField is a +builder:required field rendered into a type's
+// Validate() method.
+type requiredField struct {
+	Name     string
+	ZeroExpr string // e.g. "\"\"", "0", "nil" - whatever compares equal to the field's zero value
+}
+
+// zeroExprFor returns a Go expression that compares equal to the zero value
+// of a rendered field type, for use in a Validate() nil/empty check.
+// Slice, map and func types are left out by the caller since they aren't
+// comparable with ==.
+func zeroExprFor(fieldType string) string {
+	switch {
+	case strings.HasPrefix(fieldType, "*"), fieldType == "error":
+		return "nil"
+	case fieldType == "string":
+		return `""`
+	case strings.HasPrefix(fieldType, "[]"), strings.HasPrefix(fieldType, "map["):
+		return "" // not comparable; caller skips these
+	default:
+		return "0"
+	}
+}
+
+// embeddedField scans fields for an embedded (unnamed) field named fieldName
+// (e.g. "TypeMeta", "ObjectMeta") and returns its selector expression, or nil
+// if no such field exists. This does no type resolution of its own, so
+// callers that only need to know whether the field is present - not render
+// its type - don't risk side effects like emit.go's usage-tracking
+// qualifier closure firing for a field that ends up unused.
+func embeddedField(fields []*ast.Field, fieldName string) *ast.SelectorExpr {
+	for _, f := range fields {
+		if len(f.Names) != 0 {
+			continue
+		}
+		sel, ok := f.Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != fieldName {
+			continue
+		}
+		return sel
+	}
+	return nil
+}
+
+// embeddedFieldType scans fields for an embedded (unnamed) field named
+// fieldName (e.g. "TypeMeta", "ObjectMeta") and, when found, renders its
+// type through render - the same qualifier/alias resolution every other
+// field's type goes through (bg.renderFieldType for the per-file path,
+// resolveFieldType for -recursive) - so the package qualifier used for the
+// literal TypeMeta{}/ObjectMeta struct, and for types embedded inside it
+// like v1.Time, stays consistent with however the source file aliased that
+// import, instead of a hardcoded guess.
+func embeddedFieldType(fields []*ast.Field, fieldName string, render func(ast.Expr) string) (found bool, rendered string) {
+	sel := embeddedField(fields, fieldName)
+	if sel == nil {
+		return false, ""
+	}
+	return true, render(sel)
+}
+
+// generate renders the builder file for bg's collected types. Output is
+// assembled with text/template instead of ad-hoc fmt.Fprintf calls and is
+// always passed through go/format.Source before being returned, so callers
+// never have to worry about gofmt-clean output or stray whitespace from the
+// templates themselves.
+func (bg *builderGenerator) generate() ([]byte, error) {
+	var buf bytes.Buffer
+
+	imports := bg.imports
+	if bg.style.hasOptions() && bg.needsFmtImport() {
+		imports = append(imports, importSpec{path: "fmt"})
+	}
+	importStrs := make([]string, len(imports))
+	for i, imp := range imports {
+		importStrs[i] = imp.String()
+	}
+	if err := headerTmpl.Execute(&buf, struct {
+		Pkg     string
+		Imports []string
+	}{bg.pkg, importStrs}); err != nil {
+		return nil, fmt.Errorf("rendering package header: %w", err)
+	}
+
+	typeNames := make([]string, 0, len(bg.types))
+	for typeName := range bg.types {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	for _, typeName := range typeNames {
+		typeSpec := bg.types[typeName]
+		structType, ok := typeSpec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		tm := bg.typeMarkers[typeName]
+
+		kind := typeName
+		if tm.kind != "" {
+			kind = tm.kind
+		}
+		groupVersion := tm.groupVersion
+
+		// Without a +builder:groupVersion marker we have no APIVersion to
+		// stamp, so TypeMeta is left off entirely rather than guessing one.
+		// Beyond that, only stamp it when the struct actually embeds a
+		// TypeMeta field - gating on the type's name alone let a
+		// +builder:groupVersion marker on a plain (non-Spec/Status) struct
+		// with no embedded TypeMeta produce an unknown-field struct literal.
+		// The qualifier TypeMeta{} (and, via generateEmbeddedFieldFuncs,
+		// ObjectMeta's nested v1.Time fields) should use comes from the same
+		// scan, resolved the same way every other field's type is so it
+		// matches whatever alias the source file gave metav1.
+		hasTypeMeta, typeMetaQualifier := embeddedFieldType(structType.Fields.List, "TypeMeta", bg.renderFieldType)
+		hasTypeMeta = hasTypeMeta && groupVersion != ""
+		typeMetaQualifier = strings.TrimSuffix(typeMetaQualifier, ".TypeMeta")
+
+		if bg.style.hasOptions() {
+			if err := newFuncTmpl.Execute(&buf, struct {
+				Type         string
+				Kind         string
+				HasTypeMeta  bool
+				GroupVersion string
+				Qualifier    string
+			}{
+				Type:         typeName,
+				Kind:         kind,
+				HasTypeMeta:  hasTypeMeta,
+				GroupVersion: groupVersion,
+				Qualifier:    typeMetaQualifier,
+			}); err != nil {
+				return nil, fmt.Errorf("rendering New%s: %w", typeName, err)
+			}
+
+			var required []requiredField
+			visited := map[string]bool{typeName: true}
+			for _, field := range structType.Fields.List {
+				if len(field.Names) == 0 {
+					if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+						generateEmbeddedFieldFuncs(&buf, typeName, sel, bg.renderFieldType)
+					}
+					continue
+				}
+
+				fm := parseFieldMarkers(field.Doc)
+				if fm.skip {
+					continue
+				}
+
+				for _, name := range field.Names {
+					fieldType := bg.renderFieldType(field.Type)
+					funcName := withFuncName(name.Name, fm)
+
+					var err error
+					switch {
+					case fm.appendSlice:
+						if arr, ok := field.Type.(*ast.ArrayType); ok && arr.Len == nil {
+							err = withAppendSliceFuncTmpl.Execute(&buf, struct {
+								FuncName, Field, ElemType, Type string
+							}{funcName, name.Name, bg.renderFieldType(arr.Elt), typeName})
+							break
+						}
+						fallthrough
+					case fm.mapKey:
+						if m, ok := field.Type.(*ast.MapType); ok {
+							err = withMapKeyFuncTmpl.Execute(&buf, struct {
+								FuncName, Field, KeyType, ValueType, Type string
+							}{funcName, name.Name, bg.renderFieldType(m.Key), bg.renderFieldType(m.Value), typeName})
+							break
+						}
+						fallthrough
+					default:
+						err = withFuncTmpl.Execute(&buf, struct {
+							FuncName, Field, Param, FieldType, Type string
+						}{funcName, name.Name, strings.ToLower(name.Name), fieldType, typeName})
+					}
+					if err != nil {
+						return nil, fmt.Errorf("rendering %s: %w", funcName, err)
+					}
+
+					if fm.required {
+						if zero := zeroExprFor(fieldType); zero != "" {
+							required = append(required, requiredField{Name: name.Name, ZeroExpr: zero})
+						}
+					}
+
+					if err := bg.generateNestedBuilderHelpers(&buf, typeName, name.Name, field.Type, visited); err != nil {
+						return nil, fmt.Errorf("rendering nested builder for %s.%s: %w", typeName, name.Name, err)
+					}
+				}
+			}
+
+			if len(required) > 0 {
+				if err := validateFuncTmpl.Execute(&buf, struct {
+					Type           string
+					RequiredFields []requiredField
+				}{typeName, required}); err != nil {
+					return nil, fmt.Errorf("rendering %s.Validate: %w", typeName, err)
+				}
+			}
+		}
+
+		if bg.style.hasFluent() {
+			if err := bg.generateFluentBuilder(&buf, typeName, structType, kind, groupVersion); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w\n%s", err, buf.String())
+	}
+
+	return formatted, nil
+}
+
+// needsFmtImport reports whether any collected type has a +builder:required
+// field, which means generate will emit a Validate() method using
+// fmt.Errorf. Validate() is only emitted for options-style output, so
+// callers must also check bg.style.hasOptions() before trusting this.
+func (bg *builderGenerator) needsFmtImport() bool {
+	for _, imp := range bg.imports {
+		if imp.path == "fmt" {
+			return false
+		}
+	}
+	for _, typeSpec := range bg.types {
+		structType, ok := typeSpec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range structType.Fields.List {
+			if parseFieldMarkers(field.Doc).required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderFieldType renders a struct field's type for use in generated
+// signatures. It prefers the type go/types resolved for expr (following
+// named types, aliases and qualified identifiers through the type checker),
+// falling back to plain AST inspection when expr couldn't be resolved - most
+// commonly because it references a package the single-file check in
+// parseSource has no way to load.
+func (bg *builderGenerator) renderFieldType(expr ast.Expr) string {
+	if bg.typeInfo != nil {
+		if tv, ok := bg.typeInfo.info.Types[expr]; ok && tv.Type != nil && tv.Type != types.Typ[types.Invalid] {
+			return types.TypeString(tv.Type, bg.qualifier())
+		}
+	}
+	return renderType(expr)
+}
+
+// generateEmbeddedFieldFuncs emits With<Type>Name/With<Type>Namespace/etc.
+// option funcs for an embedded metav1.ObjectMeta. The functions are
+// package-level (this is the options-style path), so they're namespaced
+// with typeName - a plain WithName would collide as soon as two +builder
+// types embedding ObjectMeta live in the same package, which is the normal
+// Foo/FooSpec/FooStatus shape. render resolves typeExpr's own package
+// qualifier (the same way every other field type is resolved) so the
+// v1.Time/metav1.Time references inside match whatever alias the source
+// file gave that import. Shared by the per-file (generate) and -recursive
+// (emitPackageBuilders) paths.
+func generateEmbeddedFieldFuncs(buf *bytes.Buffer, typeName string, typeExpr *ast.SelectorExpr, render func(ast.Expr) string) {
+	if typeExpr.Sel.Name != "ObjectMeta" {
+		return
+	}
+	qualifier := strings.TrimSuffix(render(typeExpr), ".ObjectMeta")
+
+	fmt.Fprintf(buf, "// With%sName sets the name of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sName(name string) func(*%s) {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tobj.Name = name\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sNamespace sets the namespace of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sNamespace(namespace string) func(*%s) {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tobj.Namespace = namespace\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sLabel sets a label of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sLabel(k, v string) func(*%s) {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tif obj.Labels == nil {\n")
+	fmt.Fprintf(buf, "\t\t\tobj.Labels = make(map[string]string)\n")
+	fmt.Fprintf(buf, "\t\t}\n")
+	fmt.Fprintf(buf, "\t\tobj.Labels[k] = v\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sAnnotation sets an annotation of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sAnnotation(k, v string) func(*%s) {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tif obj.Annotations == nil {\n")
+	fmt.Fprintf(buf, "\t\t\tobj.Annotations = make(map[string]string)\n")
+	fmt.Fprintf(buf, "\t\t}\n")
+	fmt.Fprintf(buf, "\t\tobj.Annotations[k] = v\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sFinalizer sets the finalizers of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sFinalizer(f string) func(*%s) {\n", typeName, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tobj.Finalizers = append(obj.Finalizers, f)\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sCreationTimestamp sets the deletion timestamp of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sCreationTimestamp(timestamp %s.Time) func(*%s) {\n", typeName, qualifier, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tobj.CreationTimestamp = timestamp\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+
+	fmt.Fprintf(buf, "// With%sDeletionTimestamp sets the deletion timestamp of the %s\n", typeName, typeName)
+	fmt.Fprintf(buf, "func With%sDeletionTimestamp(timestamp *%s.Time) func(*%s) {\n", typeName, qualifier, typeName)
+	fmt.Fprintf(buf, "\treturn func(obj *%s) {\n", typeName)
+	fmt.Fprintf(buf, "\t\tobj.DeletionTimestamp = timestamp\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "}\n\n")
+}
+
+// renderType is the AST-only fallback used when go/types couldn't resolve a
+// field's type (typically because it references a package this tool has no
+// way to load in isolation, e.g. most Kubernetes API packages).
+func renderType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + renderType(t.X)
+	case *ast.SelectorExpr:
+		return renderType(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + renderType(t.Elt)
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s", renderType(t.Key), renderType(t.Value))
+	default:
+		return fmt.Sprintf("unsupported-%T", expr)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+// genStyle selects which builder API(s) generate emits: the existing
+// functional-options API (NewFoo(WithX(x))), a fluent chained-method API
+// (NewFooBuilder().WithX(x).Build()), or both side by side in the same
+// output file.
+type genStyle string
+
+const (
+	styleOptions genStyle = "options"
+	styleFluent  genStyle = "fluent"
+	styleBoth    genStyle = "both"
+)
+
+// parseGenStyle validates the -style flag value.
+func parseGenStyle(s string) (genStyle, error) {
+	switch genStyle(s) {
+	case styleOptions, styleFluent, styleBoth:
+		return genStyle(s), nil
+	default:
+		return "", fmt.Errorf("invalid -style %q: must be one of options, fluent, both", s)
+	}
+}
+
+func (s genStyle) hasOptions() bool { return s == styleOptions || s == styleBoth }
+func (s genStyle) hasFluent() bool  { return s == styleFluent || s == styleBoth }